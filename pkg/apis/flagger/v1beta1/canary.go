@@ -94,6 +94,80 @@ type CanarySpec struct {
 	// SkipAnalysis promotes the canary without analysing it
 	// +optional
 	SkipAnalysis bool `json:"skipAnalysis,omitempty"`
+
+	// Deployment holds settings specific to the canary Deployment
+	// generated from TargetRef
+	// +optional
+	Deployment *CanaryDeployment `json:"deployment,omitempty"`
+
+	// RollingStyle determines how traffic is shifted to the canary
+	// during analysis (Canary, AB or BlueGreen)
+	// Defaults to Canary
+	// +optional
+	RollingStyle RollingStyle `json:"rollingStyle,omitempty"`
+}
+
+// RollingStyle defines how Flagger shifts traffic to the canary
+type RollingStyle string
+
+const (
+	// CanaryRollingStyle shifts traffic gradually, step by step,
+	// based on Analysis.StepWeight and Analysis.MaxWeight
+	CanaryRollingStyle RollingStyle = "Canary"
+	// ABRollingStyle routes requests to the canary based on
+	// Analysis.Match conditions, without shifting weighted traffic
+	ABRollingStyle RollingStyle = "AB"
+	// BlueGreenRollingStyle keeps 100% of the live traffic on the
+	// primary during analysis and performs an atomic cutover on promotion
+	BlueGreenRollingStyle RollingStyle = "BlueGreen"
+)
+
+// reservedPodLabels are the selector labels Flagger sets on the primary
+// and canary deployments, and cannot be overridden via
+// CanaryDeployment.PatchPodTemplateMetadata
+var reservedPodLabels = map[string]bool{
+	"app":                    true,
+	"app.kubernetes.io/name": true,
+	"name":                   true,
+}
+
+// CanaryDeployment holds settings specific to the canary Deployment
+// generated from TargetRef
+type CanaryDeployment struct {
+	// PatchPodTemplateMetadata are extra labels and annotations added
+	// only to the pod template of the generated -canary Deployment,
+	// stripped again on promotion. The primary Deployment is never
+	// patched.
+	// +optional
+	PatchPodTemplateMetadata *PatchPodTemplateMetadata `json:"patchPodTemplateMetadata,omitempty"`
+}
+
+// PatchPodTemplateMetadata defines the labels and annotations applied to
+// the canary pod template
+type PatchPodTemplateMetadata struct {
+	// Labels added to the canary pod template
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations added to the canary pod template
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ValidatePatchPodTemplateMetadata checks that the patched labels don't
+// collide with the selector labels Flagger manages itself
+func (c *Canary) ValidatePatchPodTemplateMetadata() error {
+	if c.Spec.Deployment == nil || c.Spec.Deployment.PatchPodTemplateMetadata == nil {
+		return nil
+	}
+
+	for label := range c.Spec.Deployment.PatchPodTemplateMetadata.Labels {
+		if reservedPodLabels[label] {
+			return fmt.Errorf("spec.deployment.patchPodTemplateMetadata.labels cannot override the %s selector label", label)
+		}
+	}
+
+	return nil
 }
 
 // CanaryService defines how ClusterIP services, service mesh or ingress routing objects are generated
@@ -137,7 +211,10 @@ type CanaryService struct {
 	// +optional
 	TrafficPolicy *istiov1alpha3.TrafficPolicy `json:"trafficPolicy,omitempty"`
 
-	// URI match conditions for the generated service
+	// URI, header and query parameter match conditions for the generated
+	// service, translated by the router into each provider's native
+	// match syntax (not every provider supports path or query matching,
+	// see Canary.ValidateMatchConditions)
 	// +optional
 	Match []istiov1alpha3.HTTPMatchRequest `json:"match,omitempty"`
 
@@ -164,6 +241,62 @@ type CanaryService struct {
 	// Backends of the generated App Mesh virtual nodes
 	// +optional
 	Backends []string `json:"backends,omitempty"`
+
+	// PreviewService is the name of the analysis-only Kubernetes service
+	// that points to the canary pods, used by BlueGreen rollouts to run
+	// iteration checks without exposing the canary to live traffic
+	// +optional
+	PreviewService string `json:"previewService,omitempty"`
+}
+
+// matchProviders lists the mesh/ingress providers that can translate
+// istiov1alpha3.HTTPMatchRequest.Uri and QueryParams into their own
+// native match syntax. Providers not in this set only support header
+// based matching and are rejected at admission when Uri or QueryParams
+// are set on a match condition.
+var matchProviders = map[string]bool{
+	"":            true, // defaults to Istio
+	"istio":       true,
+	"nginx":       true,
+	"appmesh":     true,
+	"gloo":        true,
+	"contour":     true,
+	"kubernetes":  true,
+	"smi:appmesh": true,
+	"smi:istio":   true,
+	"smi:nginx":   true,
+	"smi:contour": true,
+}
+
+// hasPathOrQueryMatch returns true if any of the given match conditions
+// set a URI or query parameter match
+func hasPathOrQueryMatch(matches []istiov1alpha3.HTTPMatchRequest) bool {
+	for _, m := range matches {
+		if m.Uri != nil || len(m.QueryParams) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateMatchConditions checks that the path and query parameter match
+// conditions set on CanaryService.Match and CanaryAnalysis.Match are
+// supported by the configured provider
+func (c *Canary) ValidateMatchConditions() error {
+	provider := c.Spec.Provider
+	if matchProviders[provider] {
+		return nil
+	}
+
+	if hasPathOrQueryMatch(c.Spec.Service.Match) {
+		return fmt.Errorf("provider %s does not support path or query parameter match conditions", provider)
+	}
+
+	if c.GetAnalysis() != nil && hasPathOrQueryMatch(c.GetAnalysis().Match) {
+		return fmt.Errorf("provider %s does not support path or query parameter match conditions", provider)
+	}
+
+	return nil
 }
 
 // CanaryAnalysis is used to describe how the analysis should be done
@@ -201,7 +334,7 @@ type CanaryAnalysis struct {
 	// +optional
 	Webhooks []CanaryWebhook `json:"webhooks,omitempty"`
 
-	// A/B testing HTTP header match conditions
+	// A/B testing HTTP header, URI and query parameter match conditions
 	// +optional
 	Match []istiov1alpha3.HTTPMatchRequest `json:"match,omitempty"`
 }
@@ -276,6 +409,8 @@ const (
 	ConfirmRolloutHook HookType = "confirm-rollout"
 	// ConfirmPromotionHook halt canary promotion until webhook returns HTTP 200
 	ConfirmPromotionHook HookType = "confirm-promotion"
+	// ConfirmTrafficIncreaseHook halt traffic increase until webhook returns HTTP 200
+	ConfirmTrafficIncreaseHook HookType = "confirm-traffic-increase"
 	// EventHook dispatches Flagger events to the specified endpoint
 	EventHook HookType = "event"
 	// RollbackHook rollback canary analysis if webhook returns HTTP 200
@@ -312,6 +447,9 @@ type CanaryWebhookPayload struct {
 	// Phase of the canary analysis
 	Phase CanaryPhase `json:"phase"`
 
+	// RollingStyle of the canary analysis
+	RollingStyle RollingStyle `json:"rollingStyle,omitempty"`
+
 	// Metadata (key-value pairs) for this webhook
 	Metadata map[string]string `json:"metadata,omitempty"`
 }
@@ -395,6 +533,16 @@ func (c *Canary) GetMetricInterval() string {
 	return MetricInterval
 }
 
+// GetMetricIntervalFor returns the interval the metric should be queried
+// on: CanaryMetric.Interval when set, falling back to GetMetricInterval
+// so slow providers can use a larger window than the analysis interval
+func (c *Canary) GetMetricIntervalFor(metric CanaryMetric) string {
+	if metric.Interval != "" {
+		return metric.Interval
+	}
+	return c.GetMetricInterval()
+}
+
 // SkipAnalysis returns true if the analysis is nil
 // or if spec.SkipAnalysis is true
 func (c *Canary) SkipAnalysis() bool {
@@ -402,4 +550,28 @@ func (c *Canary) SkipAnalysis() bool {
 		return true
 	}
 	return c.Spec.SkipAnalysis
-}
\ No newline at end of file
+}
+
+// GetRollingStyle returns the rolling style (default Canary)
+func (c *Canary) GetRollingStyle() RollingStyle {
+	if c.Spec.RollingStyle == "" {
+		return CanaryRollingStyle
+	}
+	return c.Spec.RollingStyle
+}
+
+// IsBlueGreen returns true if the rolling style is BlueGreen
+func (c *Canary) IsBlueGreen() bool {
+	return c.GetRollingStyle() == BlueGreenRollingStyle
+}
+
+// ValidateRollingStyle checks that a BlueGreen Canary declares the
+// Service.PreviewService it runs its analysis against, since BlueGreen
+// keeps 100% of live traffic on the primary and has no other way to
+// reach the canary pods during analysis
+func (c *Canary) ValidateRollingStyle() error {
+	if c.IsBlueGreen() && c.Spec.Service.PreviewService == "" {
+		return fmt.Errorf("spec.service.previewService is required when spec.rollingStyle is %s", BlueGreenRollingStyle)
+	}
+	return nil
+}
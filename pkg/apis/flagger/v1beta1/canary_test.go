@@ -0,0 +1,170 @@
+/*
+Copyright 2018 The Flagger Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	istiov1alpha3 "github.com/weaveworks/flagger/pkg/apis/istio/v1alpha3"
+)
+
+func newCanaryWithMatch(provider string, match []istiov1alpha3.HTTPMatchRequest) *Canary {
+	return &Canary{
+		Spec: CanarySpec{
+			Provider: provider,
+			Service: CanaryService{
+				Match: match,
+			},
+		},
+	}
+}
+
+func TestCanary_ValidateMatchConditions(t *testing.T) {
+	pathMatch := []istiov1alpha3.HTTPMatchRequest{
+		{
+			Uri: &istiov1alpha3.StringMatch{
+				Prefix: "/api/v2",
+			},
+		},
+	}
+
+	t.Run("supported provider allows path match", func(t *testing.T) {
+		c := newCanaryWithMatch("istio", pathMatch)
+		if err := c.ValidateMatchConditions(); err != nil {
+			t.Errorf("expected no error for supported provider, got %v", err)
+		}
+	})
+
+	t.Run("unsupported provider rejects path match", func(t *testing.T) {
+		c := newCanaryWithMatch("smi:linkerd", pathMatch)
+		if err := c.ValidateMatchConditions(); err == nil {
+			t.Error("expected an error for unsupported provider, got nil")
+		}
+	})
+
+	t.Run("unsupported provider allows header-only match", func(t *testing.T) {
+		headerMatch := []istiov1alpha3.HTTPMatchRequest{
+			{
+				Headers: map[string]istiov1alpha3.StringMatch{
+					"x-canary": {Exact: "insider"},
+				},
+			},
+		}
+		c := newCanaryWithMatch("smi:linkerd", headerMatch)
+		if err := c.ValidateMatchConditions(); err != nil {
+			t.Errorf("expected no error when no path or query match is set, got %v", err)
+		}
+	})
+}
+
+func TestCanary_ValidatePatchPodTemplateMetadata(t *testing.T) {
+	t.Run("nil deployment short-circuits", func(t *testing.T) {
+		c := &Canary{}
+		if err := c.ValidatePatchPodTemplateMetadata(); err != nil {
+			t.Errorf("expected no error when spec.deployment is nil, got %v", err)
+		}
+	})
+
+	t.Run("nil patchPodTemplateMetadata short-circuits", func(t *testing.T) {
+		c := &Canary{Spec: CanarySpec{Deployment: &CanaryDeployment{}}}
+		if err := c.ValidatePatchPodTemplateMetadata(); err != nil {
+			t.Errorf("expected no error when patchPodTemplateMetadata is nil, got %v", err)
+		}
+	})
+
+	t.Run("reserved label is rejected", func(t *testing.T) {
+		for _, label := range []string{"app", "app.kubernetes.io/name"} {
+			c := &Canary{
+				Spec: CanarySpec{
+					Deployment: &CanaryDeployment{
+						PatchPodTemplateMetadata: &PatchPodTemplateMetadata{
+							Labels: map[string]string{label: "canary"},
+						},
+					},
+				},
+			}
+			if err := c.ValidatePatchPodTemplateMetadata(); err == nil {
+				t.Errorf("expected an error for reserved label %q, got nil", label)
+			}
+		}
+	})
+
+	t.Run("non-reserved label passes", func(t *testing.T) {
+		c := &Canary{
+			Spec: CanarySpec{
+				Deployment: &CanaryDeployment{
+					PatchPodTemplateMetadata: &PatchPodTemplateMetadata{
+						Labels: map[string]string{"version": "canary"},
+					},
+				},
+			},
+		}
+		if err := c.ValidatePatchPodTemplateMetadata(); err != nil {
+			t.Errorf("expected no error for a non-reserved label, got %v", err)
+		}
+	})
+}
+
+func TestCanary_GetRollingStyle(t *testing.T) {
+	t.Run("defaults to Canary", func(t *testing.T) {
+		c := &Canary{}
+		if style := c.GetRollingStyle(); style != CanaryRollingStyle {
+			t.Errorf("expected default rolling style %s, got %s", CanaryRollingStyle, style)
+		}
+		if c.IsBlueGreen() {
+			t.Error("expected IsBlueGreen to be false by default")
+		}
+	})
+
+	t.Run("honors an explicit rolling style", func(t *testing.T) {
+		c := &Canary{Spec: CanarySpec{RollingStyle: BlueGreenRollingStyle}}
+		if style := c.GetRollingStyle(); style != BlueGreenRollingStyle {
+			t.Errorf("expected rolling style %s, got %s", BlueGreenRollingStyle, style)
+		}
+		if !c.IsBlueGreen() {
+			t.Error("expected IsBlueGreen to be true")
+		}
+	})
+}
+
+func TestCanary_ValidateRollingStyle(t *testing.T) {
+	t.Run("BlueGreen without a preview service is rejected", func(t *testing.T) {
+		c := &Canary{Spec: CanarySpec{RollingStyle: BlueGreenRollingStyle}}
+		if err := c.ValidateRollingStyle(); err == nil {
+			t.Error("expected an error when rollingStyle is BlueGreen and previewService is unset")
+		}
+	})
+
+	t.Run("BlueGreen with a preview service passes", func(t *testing.T) {
+		c := &Canary{
+			Spec: CanarySpec{
+				RollingStyle: BlueGreenRollingStyle,
+				Service:      CanaryService{PreviewService: "podinfo-preview"},
+			},
+		}
+		if err := c.ValidateRollingStyle(); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("Canary rolling style does not require a preview service", func(t *testing.T) {
+		c := &Canary{}
+		if err := c.ValidateRollingStyle(); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+}
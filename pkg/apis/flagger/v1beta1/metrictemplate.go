@@ -0,0 +1,200 @@
+/*
+Copyright 2018 The Flagger Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	MetricTemplateKind = "MetricTemplate"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MetricTemplate is a specification for a MetricTemplate resource
+type MetricTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec MetricTemplateSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MetricTemplateList is a list of MetricTemplate resources
+type MetricTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []MetricTemplate `json:"items"`
+}
+
+// MetricTemplateProviderType is the type of a MetricTemplate provider
+type MetricTemplateProviderType string
+
+const (
+	PrometheusMetricProvider    MetricTemplateProviderType = "prometheus"
+	DatadogMetricProvider       MetricTemplateProviderType = "datadog"
+	CloudWatchMetricProvider    MetricTemplateProviderType = "cloudwatch"
+	StackdriverMetricProvider   MetricTemplateProviderType = "stackdriver"
+	OpenTelemetryMetricProvider MetricTemplateProviderType = "opentelemetry"
+	DynatraceMetricProvider     MetricTemplateProviderType = "dynatrace"
+	NewRelicMetricProvider      MetricTemplateProviderType = "newrelic"
+)
+
+// providerMinInterval overrides minMetricInterval for providers whose
+// query quotas require a coarser granularity than the 5s package default
+var providerMinInterval = map[MetricTemplateProviderType]time.Duration{
+	OpenTelemetryMetricProvider: 30 * time.Second,
+	DynatraceMetricProvider:     time.Minute,
+	NewRelicMetricProvider:      time.Minute,
+}
+
+// MetricTemplateProvider selects the backend a MetricTemplate queries.
+// CloudWatch, Datadog, Prometheus and Stackdriver were already supported;
+// OpenTelemetry, Dynatrace and NewRelic extend the set.
+type MetricTemplateProvider struct {
+	// Type of the metrics provider
+	Type MetricTemplateProviderType `json:"type"`
+
+	// Address of the metrics provider
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// SecretRef points to the secret containing the provider credentials
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// Region of the CloudWatch metrics
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// OpenTelemetry holds the config specific to the opentelemetry provider
+	// +optional
+	OpenTelemetry *OpenTelemetryProvider `json:"openTelemetry,omitempty"`
+
+	// Dynatrace holds the config specific to the dynatrace provider
+	// +optional
+	Dynatrace *DynatraceProvider `json:"dynatrace,omitempty"`
+
+	// NewRelic holds the config specific to the newrelic provider
+	// +optional
+	NewRelic *NewRelicProvider `json:"newrelic,omitempty"`
+}
+
+// OpenTelemetryProvider holds the config for querying an OpenTelemetry
+// Collector that exposes metrics over a Prometheus-compatible endpoint
+type OpenTelemetryProvider struct {
+	// Endpoint of the OpenTelemetry Collector's PromQL-over-OTel API
+	Endpoint string `json:"endpoint"`
+}
+
+// DynatraceProvider holds the config for querying Dynatrace metrics
+// via DQL or the metrics API v2
+type DynatraceProvider struct {
+	// Endpoint of the Dynatrace environment API
+	Endpoint string `json:"endpoint"`
+}
+
+// NewRelicProvider holds the config for querying New Relic metrics via NRQL
+type NewRelicProvider struct {
+	// AccountID of the New Relic account to query
+	AccountID string `json:"accountId"`
+
+	// Region of the New Relic account (us or eu)
+	// +optional
+	Region string `json:"region,omitempty"`
+}
+
+// MetricTemplateSpec is a specification of the desired behavior of the metric template
+type MetricTemplateSpec struct {
+	// Provider of this metric template
+	Provider MetricTemplateProvider `json:"provider"`
+
+	// Query of this metric template, supports the {{ .Name }},
+	// {{ .Namespace }}, {{ .Target }} and {{ .Interval }} placeholders,
+	// substituted with the Canary name, namespace, target workload and
+	// CanaryMetric.Interval before being sent to the provider
+	Query string `json:"query"`
+}
+
+// minMetricInterval is the smallest interval accepted for a metric query,
+// below which most providers either reject the request or rate limit it;
+// overridden per provider type via providerMinInterval
+const minMetricInterval = 5 * time.Second
+
+// ValidateInterval checks that CanaryMetric.Interval, when set, parses to
+// a duration that meets the configured provider's quota, falling back to
+// minMetricInterval for providers with no quota override
+func (mt *MetricTemplate) ValidateInterval(interval string) error {
+	if interval == "" {
+		return nil
+	}
+
+	d, err := time.ParseDuration(interval)
+	if err != nil {
+		return fmt.Errorf("invalid metric interval %s: %w", interval, err)
+	}
+
+	min := minMetricInterval
+	if providerMin, ok := providerMinInterval[mt.Spec.Provider.Type]; ok {
+		min = providerMin
+	}
+
+	if d < min {
+		return fmt.Errorf("metric interval %s is below the %s minimum for the %s provider", interval, min, mt.Spec.Provider.Type)
+	}
+
+	return nil
+}
+
+// QueryRenderData holds the values substituted into MetricTemplateSpec.Query
+type QueryRenderData struct {
+	// Name of the canary
+	Name string
+	// Namespace of the canary
+	Namespace string
+	// Target workload kind/name referenced by the canary
+	Target string
+	// Interval the metric should be queried on
+	Interval string
+}
+
+// RenderQuery substitutes the {{ .Name }}, {{ .Namespace }}, {{ .Target }}
+// and {{ .Interval }} placeholders in MetricTemplateSpec.Query with the
+// given values before the query is sent to the provider
+func (mt *MetricTemplate) RenderQuery(data QueryRenderData) (string, error) {
+	t, err := template.New(mt.Name).Parse(mt.Spec.Query)
+	if err != nil {
+		return "", fmt.Errorf("invalid query template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render query template: %w", err)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2018 The Flagger Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import "testing"
+
+func TestMetricTemplate_RenderQuery(t *testing.T) {
+	mt := &MetricTemplate{
+		Spec: MetricTemplateSpec{
+			Query: `builtin:service.response.time:filter(eq(dt.entity.service,"{{ .Name }}")):splitBy():avg:sort(value(avg,descending)):limit(20):interval({{ .Interval }})`,
+		},
+	}
+
+	got, err := mt.RenderQuery(QueryRenderData{
+		Name:      "podinfo",
+		Namespace: "test",
+		Target:    "deployment/podinfo",
+		Interval:  "1m",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `builtin:service.response.time:filter(eq(dt.entity.service,"podinfo")):splitBy():avg:sort(value(avg,descending)):limit(20):interval(1m)`
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestMetricTemplate_ValidateInterval(t *testing.T) {
+	t.Run("defaults to package minimum", func(t *testing.T) {
+		mt := &MetricTemplate{Spec: MetricTemplateSpec{Provider: MetricTemplateProvider{Type: PrometheusMetricProvider}}}
+		if err := mt.ValidateInterval("5s"); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		if err := mt.ValidateInterval("1s"); err == nil {
+			t.Error("expected an error for an interval below the package minimum")
+		}
+	})
+
+	t.Run("enforces provider specific minimum", func(t *testing.T) {
+		mt := &MetricTemplate{Spec: MetricTemplateSpec{Provider: MetricTemplateProvider{Type: DynatraceMetricProvider}}}
+		if err := mt.ValidateInterval("30s"); err == nil {
+			t.Error("expected an error for an interval below the dynatrace minimum")
+		}
+		if err := mt.ValidateInterval("1m"); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2018 The Flagger Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	RolloutKind = "Rollout"
+	// RolloutIDLabelKey is propagated onto child Canary resources and
+	// into their webhook payloads so external systems can correlate
+	// all the releases triggered by a single Rollout
+	RolloutIDLabelKey = "flagger.app/rollout-id"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Rollout is the configuration for a cross-workload release,
+// which coordinates the start, pause, promotion and rollback
+// of an ordered chain of Canary resources
+type Rollout struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RolloutSpec   `json:"spec"`
+	Status RolloutStatus `json:"status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RolloutList is a list of Rollout resources
+type RolloutList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []Rollout `json:"items"`
+}
+
+// RolloutSpec is the specification of the desired behavior of the Rollout
+type RolloutSpec struct {
+	// Canaries is the ordered dependency graph of the Canary resources
+	// managed by this Rollout, released upstream first
+	Canaries []RolloutCanaryRef `json:"canaries"`
+}
+
+// RolloutCanaryRef references a Canary resource that takes part in the
+// Rollout and the upstream releases it depends on
+type RolloutCanaryRef struct {
+	// Name of the Canary resource
+	Name string `json:"name"`
+
+	// Namespace of the Canary resource
+	// Defaults to the Rollout namespace
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// DependsOn lists the Canary resources that must reach
+	// CanaryPhaseSucceeded before this one starts
+	// +optional
+	DependsOn []RolloutDependencyRef `json:"dependsOn,omitempty"`
+}
+
+// RolloutDependencyRef references an upstream Canary resource that a
+// RolloutCanaryRef depends on
+type RolloutDependencyRef struct {
+	// Name of the upstream Canary resource
+	Name string `json:"name"`
+
+	// Namespace of the upstream Canary resource
+	// Defaults to the namespace of the dependent RolloutCanaryRef, not
+	// the Rollout's namespace, so "depend on backend in my own
+	// namespace" doesn't require repeating the namespace
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// RolloutPhase is a label for the condition of a Rollout at the current time
+type RolloutPhase string
+
+const (
+	RolloutPhaseInitializing RolloutPhase = "Initializing"
+	RolloutPhaseProgressing  RolloutPhase = "Progressing"
+	RolloutPhaseWaiting      RolloutPhase = "Waiting"
+	RolloutPhaseSucceeded    RolloutPhase = "Succeeded"
+	RolloutPhaseFailed       RolloutPhase = "Failed"
+)
+
+// RolloutStatus is used for state persistence across agent restarts
+type RolloutStatus struct {
+	// Phase aggregates the phases of every Canary managed by this Rollout
+	Phase RolloutPhase `json:"phase,omitempty"`
+
+	// CanaryStatuses tracks the phase each child Canary last reported,
+	// keyed by "namespace/name" so that Canaries with the same name in
+	// different namespaces don't collide
+	// +optional
+	CanaryStatuses map[string]CanaryPhase `json:"canaryStatuses,omitempty"`
+
+	// LastTransitionTime is the time of the last phase transition
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// GetRolloutID returns the value propagated to child Canary resources as
+// the flagger.app/rollout-id label and webhook metadata key
+func (r *Rollout) GetRolloutID() string {
+	return string(r.UID)
+}
+
+// canaryNamespace returns the effective namespace of a RolloutCanaryRef,
+// defaulting to the Rollout's own namespace
+func (r *Rollout) canaryNamespace(ref *RolloutCanaryRef) string {
+	if ref.Namespace != "" {
+		return ref.Namespace
+	}
+	return r.Namespace
+}
+
+// canaryKey returns the "namespace/name" key used to identify a child
+// Canary in RolloutStatus.CanaryStatuses, so that Canary resources with
+// the same name in different namespaces don't collide
+func canaryKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// GetCanaryRef returns the RolloutCanaryRef for the given namespace and
+// Canary name, or nil if the Canary is not part of this Rollout. An
+// empty namespace defaults to the Rollout's own namespace.
+func (r *Rollout) GetCanaryRef(namespace, name string) *RolloutCanaryRef {
+	if namespace == "" {
+		namespace = r.Namespace
+	}
+
+	for i := range r.Spec.Canaries {
+		ref := &r.Spec.Canaries[i]
+		if ref.Name == name && r.canaryNamespace(ref) == namespace {
+			return ref
+		}
+	}
+	return nil
+}
+
+// IsUpstreamSucceeded returns true when every Canary that the Canary
+// identified by namespace/name depends on has reached CanaryPhaseSucceeded.
+// Each RolloutDependencyRef.Namespace defaults to the dependent Canary's
+// own namespace (not the Rollout's), so "depend on backend" only ever
+// blocks on the backend released alongside it unless a different
+// namespace is named explicitly.
+func (r *Rollout) IsUpstreamSucceeded(namespace, name string) bool {
+	ref := r.GetCanaryRef(namespace, name)
+	if ref == nil {
+		return false
+	}
+	dependentNamespace := r.canaryNamespace(ref)
+
+	for _, upstream := range ref.DependsOn {
+		upstreamNamespace := upstream.Namespace
+		if upstreamNamespace == "" {
+			upstreamNamespace = dependentNamespace
+		}
+
+		key := canaryKey(upstreamNamespace, upstream.Name)
+		if r.Status.CanaryStatuses[key] != CanaryPhaseSucceeded {
+			return false
+		}
+	}
+
+	return true
+}
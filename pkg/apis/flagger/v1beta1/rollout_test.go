@@ -0,0 +1,90 @@
+/*
+Copyright 2018 The Flagger Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestRollout() *Rollout {
+	return &Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+		},
+		Spec: RolloutSpec{
+			Canaries: []RolloutCanaryRef{
+				{
+					Name:      "podinfo",
+					Namespace: "team-a",
+					DependsOn: []RolloutDependencyRef{{Name: "backend"}},
+				},
+				{
+					Name:      "frontend",
+					Namespace: "team-a",
+					DependsOn: []RolloutDependencyRef{{Name: "backend", Namespace: "team-b"}},
+				},
+				{Name: "backend", Namespace: "team-a"},
+				{Name: "backend", Namespace: "team-b"},
+			},
+		},
+		Status: RolloutStatus{
+			CanaryStatuses: map[string]CanaryPhase{
+				canaryKey("team-a", "backend"): CanaryPhaseSucceeded,
+				canaryKey("team-b", "backend"): CanaryPhaseProgressing,
+			},
+		},
+	}
+}
+
+func TestRollout_GetCanaryRef(t *testing.T) {
+	r := newTestRollout()
+
+	if ref := r.GetCanaryRef("team-a", "backend"); ref == nil || ref.Namespace != "team-a" {
+		t.Errorf("expected to find backend in team-a, got %v", ref)
+	}
+
+	if ref := r.GetCanaryRef("team-b", "backend"); ref == nil || ref.Namespace != "team-b" {
+		t.Errorf("expected to find backend in team-b, got %v", ref)
+	}
+}
+
+func TestRollout_IsUpstreamSucceeded(t *testing.T) {
+	r := newTestRollout()
+
+	t.Run("unqualified dependency defaults to the dependent's own namespace", func(t *testing.T) {
+		// podinfo depends on "backend" with no namespace, which must
+		// resolve to team-a/backend (already Succeeded) and must NOT be
+		// blocked by the unrelated, still-Progressing team-b/backend
+		if !r.IsUpstreamSucceeded("team-a", "podinfo") {
+			t.Error("expected podinfo's upstream to be succeeded using its own namespace's backend")
+		}
+	})
+
+	t.Run("explicit cross-namespace dependency is honored", func(t *testing.T) {
+		// frontend explicitly depends on team-b/backend, which is still progressing
+		if r.IsUpstreamSucceeded("team-a", "frontend") {
+			t.Error("expected frontend's upstream not to be succeeded while team-b/backend is still progressing")
+		}
+
+		r.Status.CanaryStatuses[canaryKey("team-b", "backend")] = CanaryPhaseSucceeded
+		if !r.IsUpstreamSucceeded("team-a", "frontend") {
+			t.Error("expected frontend's upstream to be succeeded once team-b/backend reached CanaryPhaseSucceeded")
+		}
+	})
+}